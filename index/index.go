@@ -0,0 +1,474 @@
+// Package index implements a persistent trigram index for fast repeated
+// regex searches over a directory tree, modeled after Google's codesearch
+// (csearch) index format: every indexed file contributes the set of
+// distinct 3-byte trigrams found in its contents to an inverted posting
+// list, so a query can narrow millions of files down to a small candidate
+// set before the real regex engine ever runs.
+package index
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	magic = "FSIX1\n"
+
+	// maxFileSize is the default cap on indexed file size; anything larger
+	// is skipped so one huge file can't blow up memory while indexing.
+	maxFileSize = 64 << 20 // 64MB
+
+	// binarySniffLen is how many leading bytes we inspect to guess whether
+	// a file is binary (presence of a NUL byte), same heuristic grep uses.
+	binarySniffLen = 512
+)
+
+// fileEntry is the metadata kept for every indexed file.
+type fileEntry struct {
+	Path     string
+	Size     int64
+	ModTime  int64 // UnixNano
+	Trigrams []uint32
+}
+
+// trigram packs 3 bytes into the low 24 bits of a uint32.
+func trigram(b0, b1, b2 byte) uint32 {
+	return uint32(b0)<<16 | uint32(b1)<<8 | uint32(b2)
+}
+
+// extractTrigrams returns the sorted, de-duplicated set of trigrams found
+// in data.
+func extractTrigrams(data []byte) []uint32 {
+	if len(data) < 3 {
+		return nil
+	}
+	seen := make(map[uint32]struct{})
+	for i := 0; i+2 < len(data); i++ {
+		seen[trigram(data[i], data[i+1], data[i+2])] = struct{}{}
+	}
+	out := make([]uint32, 0, len(seen))
+	for t := range seen {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// looksBinary reports whether data appears to be a non-text file, using the
+// same "NUL byte in the first chunk" heuristic grep/git use.
+func looksBinary(data []byte) bool {
+	n := len(data)
+	if n > binarySniffLen {
+		n = binarySniffLen
+	}
+	for i := 0; i < n; i++ {
+		if data[i] == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IndexWriter accumulates files and writes them out as a trigram index.
+type IndexWriter struct {
+	path        string
+	MaxFileSize int64
+
+	files  []fileEntry
+	byPath map[string]int // path -> index into files, for incremental updates
+}
+
+// Create returns a new IndexWriter that will write to path on Flush. If an
+// index already exists at path, its file list is loaded so that AddFile can
+// skip re-reading and re-scanning files whose size and mtime haven't
+// changed (incremental rebuild).
+func Create(path string) *IndexWriter {
+	w := &IndexWriter{
+		path:        path,
+		MaxFileSize: maxFileSize,
+		byPath:      make(map[string]int),
+	}
+	if old, err := Open(path); err == nil {
+		for _, f := range old.files {
+			w.byPath[f.Path] = len(w.files)
+			w.files = append(w.files, f)
+		}
+	}
+	return w
+}
+
+// AddPaths walks each of the given roots (files or directories) and indexes
+// every regular file found via AddFile. Any previously-recorded file that
+// lies under a root but is no longer found there (because it was deleted
+// or moved since the last build) is dropped from the index, so repeated
+// incremental builds don't accumulate stale entries.
+func (w *IndexWriter) AddPaths(paths []string) error {
+	for _, root := range paths {
+		seen := make(map[string]bool)
+		err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			seen[p] = true
+			return w.AddFile(p)
+		})
+		if err != nil {
+			return fmt.Errorf("index: walking %s: %w", root, err)
+		}
+		w.prune(root, seen)
+	}
+	return nil
+}
+
+// prune drops any indexed entry that lies under root but wasn't observed
+// in seen, i.e. a file the walk that populated seen no longer found there.
+func (w *IndexWriter) prune(root string, seen map[string]bool) {
+	kept := w.files[:0]
+	for _, fe := range w.files {
+		if isUnder(fe.Path, root) && !seen[fe.Path] {
+			continue
+		}
+		kept = append(kept, fe)
+	}
+	w.files = kept
+
+	w.byPath = make(map[string]int, len(w.files))
+	for i, fe := range w.files {
+		w.byPath[fe.Path] = i
+	}
+}
+
+// isUnder reports whether path is root itself or lies beneath it.
+func isUnder(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// AddFile indexes a single file, skipping it if it is binary or larger than
+// MaxFileSize. If the file's size and modification time match what is
+// already recorded for it, the previously computed trigram set is reused
+// and the file is not re-read.
+func (w *IndexWriter) AddFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("index: stat %s: %w", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	if info.Size() > w.MaxFileSize {
+		return nil
+	}
+
+	if i, ok := w.byPath[path]; ok {
+		existing := w.files[i]
+		if existing.Size == info.Size() && existing.ModTime == info.ModTime().UnixNano() {
+			return nil // unchanged, keep the cached trigram set
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("index: reading %s: %w", path, err)
+	}
+	if looksBinary(data) {
+		return nil
+	}
+
+	entry := fileEntry{
+		Path:     path,
+		Size:     info.Size(),
+		ModTime:  info.ModTime().UnixNano(),
+		Trigrams: extractTrigrams(data),
+	}
+
+	if i, ok := w.byPath[path]; ok {
+		w.files[i] = entry
+	} else {
+		w.byPath[path] = len(w.files)
+		w.files = append(w.files, entry)
+	}
+	return nil
+}
+
+// Flush writes the accumulated index out to disk, overwriting any existing
+// file at the writer's path.
+func (w *IndexWriter) Flush() error {
+	f, err := os.Create(w.path)
+	if err != nil {
+		return fmt.Errorf("index: creating %s: %w", w.path, err)
+	}
+	defer f.Close()
+
+	bw := bufio.NewWriter(f)
+	if _, err := bw.WriteString(magic); err != nil {
+		return err
+	}
+
+	// Name table.
+	if err := binary.Write(bw, binary.LittleEndian, uint32(len(w.files))); err != nil {
+		return err
+	}
+	postings := make(map[uint32][]uint32) // trigram -> sorted file IDs
+	for id, fe := range w.files {
+		if err := writeString(bw, fe.Path); err != nil {
+			return err
+		}
+		if err := binary.Write(bw, binary.LittleEndian, fe.Size); err != nil {
+			return err
+		}
+		if err := binary.Write(bw, binary.LittleEndian, fe.ModTime); err != nil {
+			return err
+		}
+		if err := binary.Write(bw, binary.LittleEndian, uint32(len(fe.Trigrams))); err != nil {
+			return err
+		}
+		for _, t := range fe.Trigrams {
+			if err := binary.Write(bw, binary.LittleEndian, t); err != nil {
+				return err
+			}
+			postings[t] = append(postings[t], uint32(id))
+		}
+	}
+
+	// Posting table: trigram -> delta+varint encoded sorted file IDs.
+	trigrams := make([]uint32, 0, len(postings))
+	for t := range postings {
+		trigrams = append(trigrams, t)
+	}
+	sort.Slice(trigrams, func(i, j int) bool { return trigrams[i] < trigrams[j] })
+
+	if err := binary.Write(bw, binary.LittleEndian, uint32(len(trigrams))); err != nil {
+		return err
+	}
+	var varintBuf [binary.MaxVarintLen32]byte
+	for _, t := range trigrams {
+		ids := postings[t]
+		if err := binary.Write(bw, binary.LittleEndian, t); err != nil {
+			return err
+		}
+		if err := binary.Write(bw, binary.LittleEndian, uint32(len(ids))); err != nil {
+			return err
+		}
+		var prev uint32
+		for _, id := range ids {
+			n := binary.PutUvarint(varintBuf[:], uint64(id-prev))
+			if _, err := bw.Write(varintBuf[:n]); err != nil {
+				return err
+			}
+			prev = id
+		}
+	}
+
+	return bw.Flush()
+}
+
+func writeString(bw *bufio.Writer, s string) error {
+	if err := binary.Write(bw, binary.LittleEndian, uint16(len(s))); err != nil {
+		return err
+	}
+	_, err := bw.WriteString(s)
+	return err
+}
+
+// Index is a read-only, in-memory view of a trigram index loaded from disk.
+type Index struct {
+	files    []fileEntry
+	postings map[uint32][]uint32
+}
+
+// Open reads the index at path into memory.
+func Open(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	buf := make([]byte, len(magic))
+	if _, err := readFull(br, buf); err != nil {
+		return nil, fmt.Errorf("index: reading header: %w", err)
+	}
+	if string(buf) != magic {
+		return nil, fmt.Errorf("index: %s is not a file-search index", path)
+	}
+
+	var numFiles uint32
+	if err := binary.Read(br, binary.LittleEndian, &numFiles); err != nil {
+		return nil, err
+	}
+	files := make([]fileEntry, numFiles)
+	for i := range files {
+		path, err := readString(br)
+		if err != nil {
+			return nil, err
+		}
+		var size, mtime int64
+		if err := binary.Read(br, binary.LittleEndian, &size); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(br, binary.LittleEndian, &mtime); err != nil {
+			return nil, err
+		}
+		var numTrigrams uint32
+		if err := binary.Read(br, binary.LittleEndian, &numTrigrams); err != nil {
+			return nil, err
+		}
+		trigrams := make([]uint32, numTrigrams)
+		for j := range trigrams {
+			if err := binary.Read(br, binary.LittleEndian, &trigrams[j]); err != nil {
+				return nil, err
+			}
+		}
+		files[i] = fileEntry{Path: path, Size: size, ModTime: mtime, Trigrams: trigrams}
+	}
+
+	var numTrigrams uint32
+	if err := binary.Read(br, binary.LittleEndian, &numTrigrams); err != nil {
+		return nil, err
+	}
+	postings := make(map[uint32][]uint32, numTrigrams)
+	for i := uint32(0); i < numTrigrams; i++ {
+		var t uint32
+		if err := binary.Read(br, binary.LittleEndian, &t); err != nil {
+			return nil, err
+		}
+		var count uint32
+		if err := binary.Read(br, binary.LittleEndian, &count); err != nil {
+			return nil, err
+		}
+		ids := make([]uint32, count)
+		var prev uint32
+		for j := uint32(0); j < count; j++ {
+			delta, err := binary.ReadUvarint(br)
+			if err != nil {
+				return nil, err
+			}
+			prev += uint32(delta)
+			ids[j] = prev
+		}
+		postings[t] = ids
+	}
+
+	return &Index{files: files, postings: postings}, nil
+}
+
+func readFull(br *bufio.Reader, buf []byte) (int, error) {
+	n := 0
+	for n < len(buf) {
+		m, err := br.Read(buf[n:])
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func readString(br *bufio.Reader) (string, error) {
+	var n uint16
+	if err := binary.Read(br, binary.LittleEndian, &n); err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err := readFull(br, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// NumFiles returns the number of files recorded in the index.
+func (idx *Index) NumFiles() int {
+	return len(idx.files)
+}
+
+// Path returns the path of the file with the given ID.
+func (idx *Index) Path(id int) string {
+	return idx.files[id].Path
+}
+
+// ModTime returns the indexed modification time of the file with the given ID.
+func (idx *Index) ModTime(id int) time.Time {
+	return time.Unix(0, idx.files[id].ModTime)
+}
+
+// PostingQuery returns the sorted list of file IDs whose trigram set
+// contains every trigram in need. An empty need list (no extractable
+// literal) matches every indexed file.
+func (idx *Index) PostingQuery(need [][]byte) []int {
+	if len(need) == 0 {
+		all := make([]int, len(idx.files))
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+
+	lists := make([][]uint32, 0, len(need))
+	for _, t := range need {
+		if len(t) != 3 {
+			continue
+		}
+		lists = append(lists, idx.postings[trigram(t[0], t[1], t[2])])
+	}
+	if len(lists) == 0 {
+		all := make([]int, len(idx.files))
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+
+	result := lists[0]
+	for _, l := range lists[1:] {
+		result = intersect(result, l)
+		if len(result) == 0 {
+			break
+		}
+	}
+
+	out := make([]int, len(result))
+	for i, id := range result {
+		out[i] = int(id)
+	}
+	return out
+}
+
+// intersect merges two sorted, deduplicated uint32 slices.
+func intersect(a, b []uint32) []uint32 {
+	out := make([]uint32, 0, min(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
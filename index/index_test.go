@@ -0,0 +1,220 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtractTrigrams(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []uint32
+	}{
+		{name: "empty", data: "", want: nil},
+		{name: "shorter than a trigram", data: "ab", want: nil},
+		{name: "exact trigram", data: "abc", want: []uint32{trigram('a', 'b', 'c')}},
+		{
+			name: "sliding window, no duplicates",
+			data: "abcabc",
+			want: []uint32{trigram('a', 'b', 'c'), trigram('b', 'c', 'a'), trigram('c', 'a', 'b')},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractTrigrams([]byte(tt.data))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractTrigrams(%q) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+// bytesOf returns n bytes all equal to b.
+func bytesOf(b byte, n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = b
+	}
+	return out
+}
+
+func TestLooksBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{name: "plain text", data: []byte("hello, world\n"), want: false},
+		{name: "NUL byte near the start", data: []byte("hi\x00there"), want: true},
+		{name: "NUL byte beyond the sniff window is missed", data: append(bytesOf('x', binarySniffLen), 0), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := looksBinary(tt.data); got != tt.want {
+				t.Errorf("looksBinary(...) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []uint32
+		want []uint32
+	}{
+		{name: "disjoint", a: []uint32{1, 2}, b: []uint32{3, 4}, want: []uint32{}},
+		{name: "overlap", a: []uint32{1, 2, 3}, b: []uint32{2, 3, 4}, want: []uint32{2, 3}},
+		{name: "identical", a: []uint32{1, 2, 3}, b: []uint32{1, 2, 3}, want: []uint32{1, 2, 3}},
+		{name: "empty input", a: nil, b: []uint32{1, 2}, want: []uint32{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := intersect(tt.a, tt.b)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("intersect(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+// writeFile creates (or overwrites) a file with the given contents, failing
+// the test on error.
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+func TestIndexWriterFlushAndOpenRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "hello world")
+	writeFile(t, filepath.Join(dir, "b.txt"), "goodbye world")
+
+	indexPath := filepath.Join(dir, "idx")
+	w := Create(indexPath)
+	if err := w.AddPaths([]string{dir}); err != nil {
+		t.Fatalf("AddPaths: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	idx, err := Open(indexPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if idx.NumFiles() != 2 {
+		t.Fatalf("NumFiles() = %d, want 2", idx.NumFiles())
+	}
+
+	need := RequiredTrigrams("world")
+	candidates := idx.PostingQuery(need)
+	if len(candidates) != 2 {
+		t.Errorf("PostingQuery(%q) matched %d files, want 2", "world", len(candidates))
+	}
+
+	need = RequiredTrigrams("hello")
+	candidates = idx.PostingQuery(need)
+	if len(candidates) != 1 || idx.Path(candidates[0]) != filepath.Join(dir, "a.txt") {
+		t.Errorf("PostingQuery(%q) = %v, want just a.txt", "hello", candidates)
+	}
+}
+
+func TestIndexWriterIncrementalRebuildPrunesDeletedFiles(t *testing.T) {
+	dir := t.TempDir()
+	keepPath := filepath.Join(dir, "keep.txt")
+	deletedPath := filepath.Join(dir, "deleted.txt")
+	writeFile(t, keepPath, "alpha")
+	writeFile(t, deletedPath, "beta")
+
+	indexPath := filepath.Join(dir, "idx")
+	w := Create(indexPath)
+	if err := w.AddPaths([]string{dir}); err != nil {
+		t.Fatalf("AddPaths: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	if err := os.Remove(deletedPath); err != nil {
+		t.Fatal(err)
+	}
+
+	w2 := Create(indexPath)
+	if err := w2.AddPaths([]string{dir}); err != nil {
+		t.Fatalf("AddPaths (rebuild): %v", err)
+	}
+	if err := w2.Flush(); err != nil {
+		t.Fatalf("Flush (rebuild): %v", err)
+	}
+
+	idx, err := Open(indexPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if idx.NumFiles() != 1 {
+		t.Fatalf("NumFiles() after rebuild = %d, want 1", idx.NumFiles())
+	}
+	if idx.Path(0) != keepPath {
+		t.Errorf("surviving file = %s, want %s", idx.Path(0), keepPath)
+	}
+}
+
+func TestIndexWriterAddFileReusesCachedTrigramsWhenUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	writeFile(t, path, "original contents")
+
+	indexPath := filepath.Join(dir, "idx")
+	w := Create(indexPath)
+	if err := w.AddFile(path); err != nil {
+		t.Fatalf("AddFile: %v", err)
+	}
+	before := w.files[0]
+
+	// Modify the file on disk but keep size and mtime identical to what was
+	// recorded, simulating a filesystem that didn't actually change it;
+	// AddFile should treat it as unchanged and keep the cached trigram set.
+	writeFile(t, path, "modified contents")
+	if err := os.Chtimes(path, time.Unix(0, before.ModTime), time.Unix(0, before.ModTime)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddFile(path); err != nil {
+		t.Fatalf("AddFile (second call): %v", err)
+	}
+	if !reflect.DeepEqual(w.files[0], before) {
+		t.Errorf("AddFile re-scanned an unchanged file: got %+v, want %+v", w.files[0], before)
+	}
+}
+
+func TestIsUnder(t *testing.T) {
+	tests := []struct {
+		path, root string
+		want       bool
+	}{
+		{path: "/a/b/c.txt", root: "/a/b", want: true},
+		{path: "/a/b", root: "/a/b", want: true},
+		{path: "/a/bc.txt", root: "/a/b", want: false},
+		{path: "/a/c.txt", root: "/a/b", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path+" under "+tt.root, func(t *testing.T) {
+			if got := isUnder(tt.path, tt.root); got != tt.want {
+				t.Errorf("isUnder(%q, %q) = %v, want %v", tt.path, tt.root, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package index
+
+import "regexp/syntax"
+
+// RequiredTrigrams compiles pattern and returns the set of 3-byte literal
+// sequences that must appear somewhere in any string the regex matches. It
+// is a best-effort analysis of the regex AST: literal runs longer than two
+// bytes contribute every trigram they contain, a one-or-more repetition
+// keeps its sub-expression's literals, while anything involving
+// alternation, optional or zero-or-more repetition, character classes, or
+// case folding is treated as "no information" for that branch. A nil or empty result means the pattern
+// has no extractable literal and every indexed file must be treated as a
+// candidate.
+func RequiredTrigrams(pattern string) [][]byte {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	re = re.Simplify()
+
+	lits := literals(re)
+	seen := make(map[string]struct{})
+	var out [][]byte
+	for _, lit := range lits {
+		if len(lit) < 3 {
+			continue
+		}
+		for i := 0; i+2 < len(lit); i++ {
+			t := lit[i : i+3]
+			if _, ok := seen[string(t)]; ok {
+				continue
+			}
+			seen[string(t)] = struct{}{}
+			out = append(out, []byte(t))
+		}
+	}
+	return out
+}
+
+// literals returns the runs of literal runes that every match of re is
+// guaranteed to contain, conservatively: for concatenations it collects the
+// literal runs of every sub-expression; captures and one-or-more repeats
+// pass through their sub-expression's runs; for alternation, star, quest,
+// and anything else it gives up on that sub-expression rather than risk
+// claiming a trigram that some match could lack.
+func literals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			// syntax.Parse normalizes a fold-cased literal's Rune to a single
+			// case, but the indexer trigrams raw file bytes, case included;
+			// a trigram derived from that normalized form may never appear
+			// in an on-disk file that matches case-insensitively. Treat it
+			// like any other node with no fixed literal instead of risking
+			// a false negative.
+			return nil
+		}
+		return []string{string(re.Rune)}
+	case syntax.OpConcat:
+		var all []string
+		for _, sub := range re.Sub {
+			all = append(all, literals(sub)...)
+		}
+		return all
+	case syntax.OpCapture:
+		return literals(re.Sub[0])
+	case syntax.OpPlus:
+		return literals(re.Sub[0])
+	default:
+		// Star, Quest, Alternate, CharClass, AnyChar, etc. don't guarantee
+		// a fixed literal appears in every match, so contribute nothing.
+		return nil
+	}
+}
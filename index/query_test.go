@@ -0,0 +1,42 @@
+package index
+
+import "testing"
+
+func TestRequiredTrigrams(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{name: "literal longer than a trigram", pattern: "hello", want: []string{"hel", "ell", "llo"}},
+		{name: "literal shorter than a trigram", pattern: "ab", want: nil},
+		{name: "alternation contributes nothing", pattern: "foo|bar", want: nil},
+		{name: "star contributes nothing for its sub-expression", pattern: "ab*cdef", want: []string{"cde", "def"}},
+		{name: "plus keeps its sub-expression's literal", pattern: "(abc)+", want: []string{"abc"}},
+		{name: "invalid regex yields no trigrams", pattern: "(unclosed", want: nil},
+		{name: "case-insensitive literal contributes nothing", pattern: "(?i)FOOBAR", want: nil},
+		{name: "leading case-insensitive flag taints every sub-expression", pattern: "(?i)ab*cdef", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RequiredTrigrams(tt.pattern)
+			if len(got) != len(tt.want) {
+				t.Fatalf("RequiredTrigrams(%q) = %v, want %v", tt.pattern, trigramStrings(got), tt.want)
+			}
+			for i, t3 := range got {
+				if string(t3) != tt.want[i] {
+					t.Errorf("RequiredTrigrams(%q)[%d] = %q, want %q", tt.pattern, i, t3, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func trigramStrings(ts [][]byte) []string {
+	out := make([]string, len(ts))
+	for i, t := range ts {
+		out[i] = string(t)
+	}
+	return out
+}
@@ -1,22 +1,17 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"regexp"
-	"sync"
 	"time"
-)
 
-// SearchResult holds information about a found item
-type SearchResult struct {
-	Path    string
-	IsDir   bool
-	Matched string // What matched (file, dir, or regex)
-}
+	"github.com/0xRadioAc7iv/file-search/index"
+	"github.com/0xRadioAc7iv/file-search/pkg/filesearch"
+)
 
 // SearchStats tracks various statistics about the search
 type SearchStats struct {
@@ -25,186 +20,106 @@ type SearchStats struct {
 	DirsFound    int
 }
 
-func searchConcurrent(rootDir, fileName, dirName, regexPattern string, returnEarly, suppresErrors bool, maxWorkers int, logFile *os.File) (fileFound, dirFound bool, stats SearchStats, err error) {
-	var re *regexp.Regexp
-	if regexPattern != "" {
-		re, err = regexp.Compile(regexPattern)
-		if err != nil {
-			return false, false, stats, fmt.Errorf("invalid regex pattern: %v", err)
-		}
+// buildIndex builds (or incrementally updates) a trigram index at
+// indexPath covering rootDir, and writes it to disk.
+func buildIndex(rootDir, indexPath string) error {
+	start := time.Now()
+	w := index.Create(indexPath)
+	if err := w.AddPaths([]string{rootDir}); err != nil {
+		return fmt.Errorf("building index: %w", err)
 	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("writing index: %w", err)
+	}
+	fmt.Printf("Indexed %s into %s in %v\n", rootDir, indexPath, time.Since(start))
+	return nil
+}
 
-	// Create a channel to receive search results
-	resultChan := make(chan SearchResult)
-
-	// Channel to signal early termination to all workers
-	doneChan := make(chan struct{})
-
-	// Use WaitGroup to track when all goroutines are done
-	var wg sync.WaitGroup
-
-	// Create a semaphore channel to limit concurrent goroutines
-	// This prevents spawning too many goroutines at once
-	semaphore := make(chan struct{}, maxWorkers)
+// searchIndex answers a regex query against a previously built trigram
+// index: it narrows the candidate file set using the posting lists before
+// running the real regex against each candidate's contents, writing every
+// match through reporter so -format/-top apply the same way they do for a
+// non-indexed search. It prints the candidates-considered figure directly
+// to stdout, the same way buildIndex prints its own timing, since that
+// figure is index-specific diagnostic information rather than part of
+// SearchStats.
+func searchIndex(indexPath, regexPattern string, reporter Reporter) (SearchStats, error) {
+	idx, err := index.Open(indexPath)
+	if err != nil {
+		return SearchStats{}, fmt.Errorf("opening index %s: %w", indexPath, err)
+	}
 
-	// Create a mutex to protect shared variables
-	var mu sync.Mutex
+	re, err := regexp.Compile(regexPattern)
+	if err != nil {
+		return SearchStats{}, fmt.Errorf("invalid regex pattern: %v", err)
+	}
 
-	// Function to process a directory
-	var processDir func(path string, depth int)
-	processDir = func(path string, depth int) {
-		defer wg.Done()
+	need := index.RequiredTrigrams(regexPattern)
+	candidates := idx.PostingQuery(need)
 
-		// Read directory entries
-		entries, err := os.ReadDir(path)
-		if !suppresErrors && err != nil {
-			log.Printf("Error reading directory %s: %v", path, err)
-			return
+	var stats SearchStats
+	for _, id := range candidates {
+		path := idx.Path(id)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue // file may have been removed/changed since indexing
 		}
-
-		// First, queue subdirectories to be processed concurrently
-		for _, entry := range entries {
-			// Check if early termination was signaled
-			select {
-			case <-doneChan:
-				return
-			default:
-				// Continue processing
-			}
-
-			entryPath := filepath.Join(path, entry.Name())
-
-			// Check for matches
-			matched := false
-			matchType := ""
-
-			// Regex match
-			if re != nil && re.MatchString(entry.Name()) {
-				matched = true
-				matchType = "regex"
-			}
-
-			// Directory match
-			if dirName != "" && entry.IsDir() && entry.Name() == dirName {
-				matched = true
-				matchType = "dir"
-			}
-
-			// File match
-			if fileName != "" && !entry.IsDir() && entry.Name() == fileName {
-				matched = true
-				matchType = "file"
-			}
-
-			// If there's a match, send the result
-			if matched {
-				result := SearchResult{
-					Path:    entryPath,
-					IsDir:   entry.IsDir(),
-					Matched: matchType,
-				}
-
-				// Send result to channel
-				select {
-				case resultChan <- result:
-					// Successfully sent result
-				case <-doneChan:
-					return
-				}
-			}
-
-			// If it's a directory, process it concurrently
-			if entry.IsDir() {
-				wg.Add(1)
-
-				// Try to acquire a slot from the semaphore
-				// This blocks if we already have maxWorkers goroutines running
-				select {
-				case semaphore <- struct{}{}:
-					// We acquired a slot, process in a new goroutine
-					go func(dirPath string, d int) {
-						defer func() { <-semaphore }() // Release the semaphore slot when done
-						processDir(dirPath, d+1)
-					}(entryPath, depth+1)
-				case <-doneChan:
-					wg.Done() // We're not going to run this task, so decrement WaitGroup
-					return
-				default:
-					// We've hit our concurrency limit, process synchronously instead
-					processDir(entryPath, depth+1)
-				}
+		if re.Match(data) {
+			stats.RegexMatches++
+			if err := reporter.OnMatch(filesearch.Result{
+				Path:    path,
+				Matched: "regex",
+				Size:    int64(len(data)),
+				ModTime: idx.ModTime(id),
+			}); err != nil {
+				return stats, err
 			}
 		}
 	}
 
-	// Create a mutex for file logging to prevent interleaved writes
-	var logMutex sync.Mutex
-
-	// Start a goroutine to collect results
-	go func() {
-		for result := range resultChan {
-			mu.Lock()
+	fmt.Printf("Candidates considered: %d/%d\n", len(candidates), idx.NumFiles())
+	return stats, nil
+}
 
-			var outputMsg string
-			switch result.Matched {
-			case "file":
-				outputMsg = fmt.Sprintf("File found at path: %s", result.Path)
-				fmt.Println(outputMsg)
-				fileFound = true
-				stats.FilesFound++
-			case "dir":
-				outputMsg = fmt.Sprintf("Directory found at path: %s", result.Path)
-				fmt.Println(outputMsg)
+// runSearch runs a single search to completion: it drains the Result
+// channel through reporter (and logReporter, if logging is enabled),
+// tallying stats as results arrive, and returns once the search finishes
+// or fails.
+func runSearch(opts filesearch.Options, reporter, logReporter Reporter) (fileFound, dirFound, contentMatched bool, stats SearchStats, err error) {
+	searcher := filesearch.New()
+	results, errc := searcher.Search(context.Background(), opts)
+
+	matchedFiles := make(map[string]struct{})
+	for res := range results {
+		switch res.Matched {
+		case "file":
+			fileFound = true
+			stats.FilesFound++
+		case "dir":
+			dirFound = true
+			stats.DirsFound++
+		case "regex":
+			stats.RegexMatches++
+			if res.IsDir {
 				dirFound = true
-				stats.DirsFound++
-			case "regex":
-				outputMsg = fmt.Sprintf("Match found at path: %s", result.Path)
-				fmt.Println(outputMsg)
-				stats.RegexMatches++
-				if result.IsDir {
-					dirFound = true
-				} else {
-					fileFound = true
-				}
-			}
-
-			// Write to log file if provided
-			if logFile != nil {
-				logMutex.Lock()
-				fmt.Fprintf(logFile, "%s\n", outputMsg)
-				logMutex.Unlock()
-			}
-
-			// If returnEarly flag is set and we found what we're looking for
-			shouldTerminate := returnEarly
-			if returnEarly {
-				if fileName != "" && dirName != "" {
-					shouldTerminate = fileFound && dirFound
-				} else if fileName != "" {
-					shouldTerminate = fileFound
-				} else if dirName != "" {
-					shouldTerminate = dirFound
-				}
-			}
-
-			if shouldTerminate {
-				close(doneChan) // Signal all goroutines to terminate
+			} else {
+				fileFound = true
 			}
-			mu.Unlock()
+		case "content":
+			contentMatched = true
+			matchedFiles[res.Path] = struct{}{}
+			stats.RegexMatches++
+			stats.FilesFound = len(matchedFiles)
 		}
-	}()
-
-	// Start the initial search from the root directory
-	wg.Add(1)
-	go processDir(rootDir, 0)
-
-	// Wait for all goroutines to finish
-	wg.Wait()
 
-	// Close the result channel to terminate the collector goroutine
-	close(resultChan)
+		reporter.OnMatch(res)
+		if logReporter != nil {
+			logReporter.OnMatch(res)
+		}
+	}
 
-	return fileFound, dirFound, stats, nil
+	err = <-errc
+	return fileFound, dirFound, contentMatched, stats, err
 }
 
 func main() {
@@ -217,13 +132,54 @@ func main() {
 	enableLog := flag.Bool("log", false, "Log all matches to a text file")
 	logFilePath := flag.String("logfile", "search_results.log", "Path to log file (used with -log)")
 	suppresErrors := flag.Bool("noerrors", false, "whether to log errors or not")
+	indexPath := flag.String("index", "", "path to an on-disk trigram index; with -buildindex, build/update it from -root, otherwise query it with -regex")
+	buildIndexMode := flag.Bool("buildindex", false, "build/update the index at -index from -root and exit")
+	contentPattern := flag.String("content", "", "regex to match against file contents instead of names")
+	caseInsensitive := flag.Bool("i", false, "case-insensitive match (used with -content)")
+	includeBinary := flag.Bool("a", false, "treat binary files as text instead of skipping them (used with -content)")
+	beforeCtx := flag.Int("B", 0, "lines of context to print before each -content match")
+	afterCtx := flag.Int("A", 0, "lines of context to print after each -content match")
+	contextCtx := flag.Int("C", 0, "lines of context to print before and after each -content match (overrides -A/-B)")
+	maxCount := flag.Int("max-count", 0, "stop after this many -content matches per file (0 = unlimited)")
+	ignoreFile := flag.String("ignore-file", "", "additional ignore file name to honor at every directory level, alongside .gitignore and .ignore")
+	showHidden := flag.Bool("hidden", false, "include dot-files and dot-directories in the search")
+	followSymlinks := flag.Bool("follow-symlinks", false, "follow symlinked directories (cycle-safe)")
+	format := flag.String("format", "text", "output format for matches: text, jsonl, or csv")
+	listenAddr := flag.String("listen", "", "run as a TCP daemon on this address (e.g. :4000) instead of searching once; each connection sends a JSON request and receives JSONL results. The daemon is unauthenticated: anything that can reach the address can run searches, so use -listen-root to confine them and keep the address off untrusted networks")
+	listenRoot := flag.String("listen-root", ".", "restrict -listen requests to this directory subtree (used with -listen)")
+	topN := flag.Int("top", 0, "keep only the top N results, ranked by -sort, instead of returning every match (disables -r)")
+	sortBy := flag.String("sort", "size", "ranking criterion for -top: size, mtime, or name")
 	flag.Parse()
 
-	if *fileName == "" && *dirName == "" && *regexPattern == "" {
-		fmt.Println("Please provide at least one search target (-file, -dir, or -regex)")
+	var ignoreNames []string
+	if *ignoreFile != "" {
+		ignoreNames = append(ignoreNames, *ignoreFile)
+	}
+
+	if *listenAddr != "" {
+		if err := serve(*listenAddr, *listenRoot, ignoreNames, *showHidden, *followSymlinks, *suppresErrors, *workers); err != nil {
+			log.Fatal(err)
+		}
 		return
 	}
 
+	reporter, err := newReporter(*format, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer reporter.Close()
+
+	// -top requires seeing every match before it can know which ones rank
+	// highest, so it wraps reporter to hold results back and disables -r.
+	effectiveReturnEarly := *returnEarly
+	if *topN > 0 {
+		reporter, err = newTopNReporter(reporter, *topN, *sortBy)
+		if err != nil {
+			log.Fatal(err)
+		}
+		effectiveReturnEarly = false
+	}
+
 	// Validate if the root directory exists
 	if _, err := os.Stat(*rootDir); os.IsNotExist(err) {
 		log.Fatalf("Error: Specified root directory '%s' does not exist.\n", *rootDir)
@@ -231,6 +187,7 @@ func main() {
 
 	// Open log file if logging is enabled
 	var logFile *os.File
+	var logReporter Reporter
 	if *enableLog {
 		var err error
 		logFile, err = os.Create(*logFilePath)
@@ -238,6 +195,7 @@ func main() {
 			log.Fatalf("Error creating log file: %v", err)
 		}
 		defer logFile.Close()
+		logReporter = &textReporter{w: logFile}
 
 		// Write header to log file
 		timestamp := time.Now().Format("2024-03-05 15:04:05")
@@ -252,11 +210,87 @@ func main() {
 		if *regexPattern != "" {
 			fmt.Fprintf(logFile, "Regex pattern: %s\n", *regexPattern)
 		}
+		if *contentPattern != "" {
+			fmt.Fprintf(logFile, "Content pattern: %s\n", *contentPattern)
+		}
 		fmt.Fprintf(logFile, "-------------------------------------------\n")
 	}
 
+	if *contentPattern != "" {
+		before, after := *beforeCtx, *afterCtx
+		if *contextCtx > 0 {
+			before, after = *contextCtx, *contextCtx
+		}
+		opts := filesearch.Options{
+			RootDir:         *rootDir,
+			ContentPattern:  *contentPattern,
+			CaseInsensitive: *caseInsensitive,
+			IncludeBinary:   *includeBinary,
+			BeforeContext:   before,
+			AfterContext:    after,
+			MaxCount:        *maxCount,
+			ReturnEarly:     effectiveReturnEarly,
+			SuppressErrors:  *suppresErrors,
+			MaxWorkers:      *workers,
+			IgnoreNames:     ignoreNames,
+			ShowHidden:      *showHidden,
+			FollowSymlinks:  *followSymlinks,
+		}
+		_, _, matched, stats, err := runSearch(opts, reporter, logReporter)
+		if err != nil {
+			log.Fatal("Error during search: ", err)
+		}
+		if !matched {
+			fmt.Println("No matches found")
+		}
+		reporter.OnStats(stats)
+		return
+	}
+
+	if *buildIndexMode {
+		if *indexPath == "" {
+			fmt.Println("Please provide -index when using -buildindex")
+			return
+		}
+		if err := buildIndex(*rootDir, *indexPath); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *indexPath != "" {
+		if *regexPattern == "" {
+			fmt.Println("Please provide -regex when querying with -index")
+			return
+		}
+		stats, err := searchIndex(*indexPath, *regexPattern, reporter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		reporter.OnStats(stats)
+		reporter.Close()
+		return
+	}
+
+	if *fileName == "" && *dirName == "" && *regexPattern == "" {
+		fmt.Println("Please provide at least one search target (-file, -dir, or -regex)")
+		return
+	}
+
 	start := time.Now()
-	fileFound, dirFound, stats, err := searchConcurrent(*rootDir, *fileName, *dirName, *regexPattern, *returnEarly, *suppresErrors, *workers, logFile)
+	opts := filesearch.Options{
+		RootDir:        *rootDir,
+		FileName:       *fileName,
+		DirName:        *dirName,
+		RegexPattern:   *regexPattern,
+		ReturnEarly:    effectiveReturnEarly,
+		SuppressErrors: *suppresErrors,
+		MaxWorkers:     *workers,
+		IgnoreNames:    ignoreNames,
+		ShowHidden:     *showHidden,
+		FollowSymlinks: *followSymlinks,
+	}
+	fileFound, dirFound, _, stats, err := runSearch(opts, reporter, logReporter)
 
 	if err != nil {
 		log.Fatal("Error during search: ", err)
@@ -269,16 +303,7 @@ func main() {
 		fmt.Println("Directory not found")
 	}
 
-	fmt.Println("\nSearch Statistics:")
-	if *regexPattern != "" {
-		fmt.Printf("- Regex matches found: %d\n", stats.RegexMatches)
-	}
-	if *fileName != "" {
-		fmt.Printf("- Named files found: %d\n", stats.FilesFound)
-	}
-	if *dirName != "" {
-		fmt.Printf("- Named directories found: %d\n", stats.DirsFound)
-	}
+	reporter.OnStats(stats)
 
 	// Write statistics to log file if enabled
 	if *enableLog {
@@ -297,5 +322,6 @@ func main() {
 		fmt.Printf("Results written to log file: %s\n", *logFilePath)
 	}
 
+	reporter.Close()
 	fmt.Printf("Search completed in %v\n", time.Since(start))
 }
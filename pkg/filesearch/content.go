@@ -0,0 +1,220 @@
+package filesearch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/0xRadioAc7iv/file-search/pkg/ignore"
+	"golang.org/x/sync/errgroup"
+)
+
+// searchContent walks opts.RootDir the same way searchNames does, but
+// instead of matching entry names it opens each regular file and scans it
+// line-by-line for opts.ContentPattern, sending a Result per matching
+// line. File scans fan out through the same errgroup/semaphore pair used
+// for directory recursion, so opening files never pushes concurrency past
+// opts.MaxWorkers.
+func searchContent(ctx context.Context, opts Options, out chan<- Result) error {
+	pattern := opts.ContentPattern
+	if opts.CaseInsensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid regex pattern: %w", err)
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	ctx, cancel := context.WithCancel(gctx)
+	defer cancel()
+
+	semaphore := make(chan struct{}, maxWorkers(opts.MaxWorkers))
+
+	matcher := ignore.NewMatcher(opts.IgnoreNames...)
+	symlinks := newSymlinkTracker()
+
+	var walk func(path string, m *ignore.Matcher) error
+	walk = func(path string, m *ignore.Matcher) error {
+		m = m.Clone()
+		m.Push(path)
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			if !opts.SuppressErrors {
+				log.Printf("Error reading directory %s: %v", path, err)
+			}
+			return nil
+		}
+
+		for _, entry := range entries {
+			select {
+			case <-ctx.Done():
+				return nil
+			default:
+			}
+
+			entryPath := filepath.Join(path, entry.Name())
+
+			isDir, skip := resolveEntry(entryPath, entry, opts.FollowSymlinks, symlinks)
+			if skip || shouldSkipEntry(entryPath, entry, isDir, m, opts.ShowHidden) {
+				continue
+			}
+
+			if isDir {
+				dirPath, dm := entryPath, m
+				select {
+				case semaphore <- struct{}{}:
+					g.Go(func() error {
+						defer func() { <-semaphore }()
+						return walk(dirPath, dm)
+					})
+				case <-ctx.Done():
+				default:
+					if err := walk(dirPath, dm); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			// Regular file: scan it under the same semaphore so opening
+			// files competes for the same MaxWorkers budget as recursion.
+			filePath := entryPath
+			select {
+			case semaphore <- struct{}{}:
+				g.Go(func() error {
+					defer func() { <-semaphore }()
+					scanFileContent(ctx, cancel, filePath, re, opts, out)
+					return nil
+				})
+			case <-ctx.Done():
+			default:
+				scanFileContent(ctx, cancel, filePath, re, opts, out)
+			}
+		}
+		return nil
+	}
+
+	g.Go(func() error { return walk(opts.RootDir, matcher) })
+	return g.Wait()
+}
+
+// scanFileContent scans a single file line-by-line for re, sending a
+// Result for each matching line (up to opts.MaxCount, 0 meaning
+// unlimited) with opts.BeforeContext/AfterContext lines of surrounding
+// context. Files that look binary are skipped unless opts.IncludeBinary
+// is set. If opts.ReturnEarly is set, cancel is called right after the
+// first match is sent, the same way searchNames stops the whole walk once
+// its targets are found.
+func scanFileContent(ctx context.Context, cancel context.CancelFunc, path string, re *regexp.Regexp, opts Options, out chan<- Result) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	var size int64
+	var modTime time.Time
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+		modTime = info.ModTime()
+	}
+
+	sniff := make([]byte, 512)
+	n, _ := f.Read(sniff)
+	if !opts.IncludeBinary {
+		for i := 0; i < n; i++ {
+			if sniff[i] == 0 {
+				return
+			}
+		}
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var before []string
+	var offset int64
+	lineNum := 0
+	matches := 0
+
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		line := scanner.Text()
+		lineNum++
+
+		if re.MatchString(line) {
+			if opts.MaxCount > 0 && matches >= opts.MaxCount {
+				break
+			}
+			matches++
+
+			result := Result{
+				Path:       path,
+				IsDir:      false,
+				Matched:    "content",
+				Size:       size,
+				ModTime:    modTime,
+				LineNumber: lineNum,
+				LineText:   line,
+				Offset:     offset,
+				Before:     append([]string(nil), before...),
+			}
+
+			for len(result.After) < opts.AfterContext && scanner.Scan() {
+				offset += int64(len(line)) + 1
+				lineNum++
+				line = scanner.Text()
+				result.After = append(result.After, line)
+			}
+			// Account for the bytes of whichever line is now current (the
+			// matched line itself if no after-context was collected, or the
+			// last after-context line otherwise), so the next match's
+			// Offset isn't short by this line's width.
+			offset += int64(len(line)) + 1
+
+			select {
+			case out <- result:
+			case <-ctx.Done():
+				return
+			}
+			if opts.ReturnEarly {
+				cancel()
+				return
+			}
+			// Lines collected as this match's after-context were already
+			// consumed from the scanner and won't pass through the
+			// before-context bookkeeping below, so the next match's
+			// before-context has to pick up from here instead of starting
+			// over, or it silently loses whatever of this after-context
+			// falls within opts.BeforeContext.
+			before = append([]string(nil), result.After...)
+			if len(before) > opts.BeforeContext {
+				before = before[len(before)-opts.BeforeContext:]
+			}
+			continue
+		}
+
+		if opts.BeforeContext > 0 {
+			before = append(before, line)
+			if len(before) > opts.BeforeContext {
+				before = before[len(before)-opts.BeforeContext:]
+			}
+		}
+		offset += int64(len(line)) + 1
+	}
+}
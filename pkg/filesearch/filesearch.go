@@ -0,0 +1,85 @@
+// Package filesearch is the importable core of the file-search tool: it
+// walks a directory tree concurrently, matching file/directory names,
+// regex patterns, or file contents, and streams results back over a
+// channel. The file-search CLI is a thin wrapper around it.
+package filesearch
+
+import (
+	"context"
+	"time"
+)
+
+// Result describes a single match found during a search.
+type Result struct {
+	Path    string
+	IsDir   bool
+	Matched string // what matched: "file", "dir", "regex", or "content"
+
+	Size    int64     // file size at the time it was matched
+	ModTime time.Time // modification time at the time it was matched
+
+	// The fields below are only populated for content matches.
+	LineNumber int      // 1-based line number of the match
+	LineText   string   // the matching line itself
+	Offset     int64    // byte offset of the line start within the file
+	Before     []string // up to Options.BeforeContext lines preceding the match
+	After      []string // up to Options.AfterContext lines following the match
+}
+
+// Options configures a single search.
+type Options struct {
+	RootDir        string
+	FileName       string // exact file name to match
+	DirName        string // exact directory name to match
+	RegexPattern   string // regex matched against file/directory names
+	ContentPattern string // regex matched against file contents instead of names; takes priority over the name-based fields above
+
+	CaseInsensitive bool // case-insensitive match, used with ContentPattern
+	IncludeBinary   bool // scan files that look binary instead of skipping them, used with ContentPattern
+	BeforeContext   int  // lines of context before each content match
+	AfterContext    int  // lines of context after each content match
+	MaxCount        int  // stop after this many content matches per file (0 = unlimited)
+
+	ReturnEarly    bool // stop as soon as the requested targets have all been found
+	SuppressErrors bool // don't log directory/file read errors
+	MaxWorkers     int  // maximum number of concurrent workers (0 or less = unlimited)
+
+	IgnoreNames    []string // additional ignore file names to honor, alongside .gitignore and .ignore
+	ShowHidden     bool     // include dot-files and dot-directories
+	FollowSymlinks bool     // follow symlinked directories (cycle-safe)
+}
+
+// Searcher runs searches. The zero value is ready to use.
+type Searcher struct{}
+
+// New returns a ready-to-use Searcher.
+func New() *Searcher {
+	return &Searcher{}
+}
+
+// Search walks opts.RootDir and streams every match on the returned Result
+// channel. The error channel carries at most one error: either an
+// up-front configuration error (e.g. an invalid regex) or the first fatal
+// error encountered while walking. Both channels are closed once the
+// search finishes. Canceling ctx stops the search early with no error.
+func (s *Searcher) Search(ctx context.Context, opts Options) (<-chan Result, <-chan error) {
+	results := make(chan Result)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(results)
+		defer close(errc)
+
+		var err error
+		if opts.ContentPattern != "" {
+			err = searchContent(ctx, opts, results)
+		} else {
+			err = searchNames(ctx, opts, results)
+		}
+		if err != nil {
+			errc <- err
+		}
+	}()
+
+	return results, errc
+}
@@ -0,0 +1,308 @@
+package filesearch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// collect drains both of Search's channels and returns every Result
+// alongside the (possibly nil) error.
+func collect(results <-chan Result, errc <-chan error) ([]Result, error) {
+	var got []Result
+	for r := range results {
+		got = append(got, r)
+	}
+	return got, <-errc
+}
+
+func paths(results []Result) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.Path
+	}
+	sort.Strings(out)
+	return out
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+// newTree builds a small fixture directory tree shared by several tests:
+//
+//	root/
+//	  target.txt
+//	  sub/
+//	    target.txt
+//	    other.go
+//	  subdir/ (a directory named "subdir")
+func newTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "subdir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "target.txt"), "root copy")
+	writeFile(t, filepath.Join(root, "sub", "target.txt"), "nested copy")
+	writeFile(t, filepath.Join(root, "sub", "other.go"), "package sub")
+	return root
+}
+
+func TestSearchByFileName(t *testing.T) {
+	root := newTree(t)
+	s := New()
+	results, errc := s.Search(context.Background(), Options{RootDir: root, FileName: "target.txt"})
+	got, err := collect(results, errc)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	want := []string{filepath.Join(root, "sub", "target.txt"), filepath.Join(root, "target.txt")}
+	if gotPaths := paths(got); !equalStrings(gotPaths, want) {
+		t.Errorf("matched paths = %v, want %v", gotPaths, want)
+	}
+	for _, r := range got {
+		if r.Matched != "file" || r.IsDir {
+			t.Errorf("Result %+v: Matched/IsDir mismatch for a file match", r)
+		}
+	}
+}
+
+func TestSearchByDirName(t *testing.T) {
+	root := newTree(t)
+	s := New()
+	results, errc := s.Search(context.Background(), Options{RootDir: root, DirName: "subdir"})
+	got, err := collect(results, errc)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 1 || got[0].Path != filepath.Join(root, "subdir") || !got[0].IsDir {
+		t.Errorf("got %+v, want a single subdir match", got)
+	}
+}
+
+func TestSearchByRegexPattern(t *testing.T) {
+	root := newTree(t)
+	s := New()
+	results, errc := s.Search(context.Background(), Options{RootDir: root, RegexPattern: `\.go$`})
+	got, err := collect(results, errc)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 1 || got[0].Path != filepath.Join(root, "sub", "other.go") {
+		t.Errorf("got %+v, want just other.go", got)
+	}
+}
+
+func TestSearchInvalidRegexReturnsError(t *testing.T) {
+	root := newTree(t)
+	s := New()
+	results, errc := s.Search(context.Background(), Options{RootDir: root, RegexPattern: "("})
+	got, err := collect(results, errc)
+	if err == nil {
+		t.Fatal("expected an error for an invalid regex pattern")
+	}
+	if len(got) != 0 {
+		t.Errorf("expected no results alongside the error, got %v", got)
+	}
+}
+
+func TestSearchHonorsIgnoreFile(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "skip.txt\n")
+	writeFile(t, filepath.Join(root, "skip.txt"), "x")
+	writeFile(t, filepath.Join(root, "keep.txt"), "x")
+
+	s := New()
+	results, errc := s.Search(context.Background(), Options{RootDir: root, RegexPattern: `\.txt$`})
+	got, err := collect(results, errc)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 1 || got[0].Path != filepath.Join(root, "keep.txt") {
+		t.Errorf("got %+v, want only keep.txt (skip.txt is gitignored)", got)
+	}
+}
+
+func TestSearchHiddenEntriesSkippedUnlessShown(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".hidden.txt"), "x")
+
+	s := New()
+	results, errc := s.Search(context.Background(), Options{RootDir: root, RegexPattern: `\.txt$`})
+	got, _ := collect(results, errc)
+	if len(got) != 0 {
+		t.Errorf("hidden file matched without -hidden: %v", got)
+	}
+
+	results, errc = s.Search(context.Background(), Options{RootDir: root, RegexPattern: `\.txt$`, ShowHidden: true})
+	got, _ = collect(results, errc)
+	if len(got) != 1 {
+		t.Errorf("hidden file not matched with ShowHidden set: %v", got)
+	}
+}
+
+func TestSearchReturnEarlyStopsAfterFirstFileMatch(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"d1", "d2", "d3"} {
+		if err := os.Mkdir(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(root, name, "target.txt"), "x")
+	}
+
+	s := New()
+	results, errc := s.Search(context.Background(), Options{
+		RootDir:     root,
+		FileName:    "target.txt",
+		ReturnEarly: true,
+		MaxWorkers:  1,
+	})
+	got, err := collect(results, errc)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("ReturnEarly with 3 available matches returned %d results, want 1", len(got))
+	}
+}
+
+func TestSearchContentMatchesWithContext(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "needle one\nplain two\nplain three\nneedle four\n")
+
+	s := New()
+	results, errc := s.Search(context.Background(), Options{
+		RootDir:        root,
+		ContentPattern: "needle",
+		BeforeContext:  2,
+		AfterContext:   2,
+	})
+	got, err := collect(results, errc)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d content matches, want 2", len(got))
+	}
+	first, second := got[0], got[1]
+	if first.LineNumber != 1 || len(first.Before) != 0 {
+		t.Errorf("first match = %+v, want line 1 with no before-context", first)
+	}
+	if second.LineNumber != 4 {
+		t.Errorf("second match line = %d, want 4", second.LineNumber)
+	}
+	wantBefore := []string{"plain two", "plain three"}
+	if !equalStrings(second.Before, wantBefore) {
+		t.Errorf("second match Before = %v, want %v (after-context lines from the first match should carry over)", second.Before, wantBefore)
+	}
+}
+
+func TestSearchContentReturnEarlyStopsAfterFirstMatch(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"d1", "d2"} {
+		if err := os.Mkdir(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(root, name, "a.txt"), "needle\n")
+	}
+
+	s := New()
+	results, errc := s.Search(context.Background(), Options{
+		RootDir:        root,
+		ContentPattern: "needle",
+		ReturnEarly:    true,
+		MaxWorkers:     1,
+	})
+	got, err := collect(results, errc)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("ReturnEarly content search returned %d matches, want 1", len(got))
+	}
+}
+
+func TestSearchContentCaseInsensitive(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "NEEDLE\n")
+
+	s := New()
+	results, errc := s.Search(context.Background(), Options{RootDir: root, ContentPattern: "needle"})
+	got, _ := collect(results, errc)
+	if len(got) != 0 {
+		t.Errorf("case-sensitive search unexpectedly matched: %v", got)
+	}
+
+	results, errc = s.Search(context.Background(), Options{RootDir: root, ContentPattern: "needle", CaseInsensitive: true})
+	got, err := collect(results, errc)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("case-insensitive search matched %d results, want 1", len(got))
+	}
+}
+
+func TestSearchContentMaxCount(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "needle\nneedle\nneedle\n")
+
+	s := New()
+	results, errc := s.Search(context.Background(), Options{RootDir: root, ContentPattern: "needle", MaxCount: 2})
+	got, err := collect(results, errc)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d matches with MaxCount=2, want 2", len(got))
+	}
+}
+
+// TestSearchContextCancellationDoesNotPanic exercises Search under an
+// externally canceled context repeatedly: the early-termination path in
+// searchNames/searchContent cancels a derived context, and previously used
+// a close-a-channel signal that panicked if triggered more than once. This
+// stays green under -race across many iterations as a regression guard for
+// that fix.
+func TestSearchContextCancellationDoesNotPanic(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 20; i++ {
+		writeFile(t, filepath.Join(root, "f"+string(rune('a'+i))+".txt"), "needle\n")
+	}
+
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Millisecond)
+		s := New()
+		results, errc := s.Search(ctx, Options{
+			RootDir:        root,
+			ContentPattern: "needle",
+			MaxWorkers:     4,
+		})
+		for range results {
+		}
+		<-errc
+		cancel()
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
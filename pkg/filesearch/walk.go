@@ -0,0 +1,231 @@
+package filesearch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/0xRadioAc7iv/file-search/pkg/ignore"
+	"golang.org/x/sync/errgroup"
+)
+
+// symlinkTracker records the (device, inode) pairs of symlink targets
+// already visited during a walk, so that FollowSymlinks can detect and
+// skip a cycle instead of recursing forever.
+type symlinkTracker struct {
+	mu   sync.Mutex
+	seen map[[2]uint64]struct{}
+}
+
+func newSymlinkTracker() *symlinkTracker {
+	return &symlinkTracker{seen: make(map[[2]uint64]struct{})}
+}
+
+// visit records info's target as seen and reports whether it had already
+// been visited before this call.
+func (t *symlinkTracker) visit(info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false // no inode info available, nothing to dedupe against
+	}
+	key := [2]uint64{uint64(st.Dev), st.Ino}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.seen[key]; ok {
+		return true
+	}
+	t.seen[key] = struct{}{}
+	return false
+}
+
+// resolveEntry reports whether entryPath should be treated as a directory
+// for traversal purposes and whether it should be skipped outright. Plain
+// directories are always traversable; symlinks are only followed (and only
+// once per target) when followSymlinks is set.
+func resolveEntry(entryPath string, entry os.DirEntry, followSymlinks bool, symlinks *symlinkTracker) (isDir, skip bool) {
+	if entry.Type()&os.ModeSymlink == 0 {
+		return entry.IsDir(), false
+	}
+	if !followSymlinks {
+		return false, false
+	}
+	info, err := os.Stat(entryPath) // follows the symlink
+	if err != nil || !info.IsDir() {
+		return false, false
+	}
+	if symlinks.visit(info) {
+		return false, true // already visited this target; avoid a cycle
+	}
+	return true, false
+}
+
+// shouldSkipEntry reports whether entryPath should be excluded from
+// matching and traversal: dot-entries are skipped unless showHidden is
+// set, and anything covered by an active .gitignore/.ignore rule is
+// skipped regardless.
+func shouldSkipEntry(entryPath string, entry os.DirEntry, isDir bool, m *ignore.Matcher, showHidden bool) bool {
+	if !showHidden && strings.HasPrefix(entry.Name(), ".") {
+		return true
+	}
+	return m.Match(entryPath, isDir)
+}
+
+// searchNames walks opts.RootDir matching entry names against
+// opts.FileName, opts.DirName, and opts.RegexPattern, sending a Result for
+// each match. Directory recursion fans out through an errgroup.Group
+// bounded by opts.MaxWorkers, which also carries the first fatal error (if
+// any) back to the caller; early termination once the requested targets
+// are all found is signaled by canceling a context derived from the
+// group's, which - unlike the close-a-channel pattern it replaces - is
+// safe to trigger more than once.
+func searchNames(ctx context.Context, opts Options, out chan<- Result) error {
+	var re *regexp.Regexp
+	if opts.RegexPattern != "" {
+		var err error
+		re, err = regexp.Compile(opts.RegexPattern)
+		if err != nil {
+			return fmt.Errorf("invalid regex pattern: %w", err)
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	ctx, cancel := context.WithCancel(gctx)
+	defer cancel()
+
+	semaphore := make(chan struct{}, maxWorkers(opts.MaxWorkers))
+
+	var mu sync.Mutex
+	var fileFound, dirFound bool
+
+	matcher := ignore.NewMatcher(opts.IgnoreNames...)
+	symlinks := newSymlinkTracker()
+
+	var walk func(path string, m *ignore.Matcher) error
+	walk = func(path string, m *ignore.Matcher) error {
+		// Each subtree works off its own copy of the matcher so that a
+		// sibling directory's ignore file doesn't leak into this one.
+		m = m.Clone()
+		m.Push(path)
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			if !opts.SuppressErrors {
+				log.Printf("Error reading directory %s: %v", path, err)
+			}
+			return nil
+		}
+
+		for _, entry := range entries {
+			select {
+			case <-ctx.Done():
+				return nil
+			default:
+			}
+
+			entryPath := filepath.Join(path, entry.Name())
+
+			isDir, skip := resolveEntry(entryPath, entry, opts.FollowSymlinks, symlinks)
+			if skip || shouldSkipEntry(entryPath, entry, isDir, m, opts.ShowHidden) {
+				continue
+			}
+
+			matched := false
+			matchType := ""
+
+			if re != nil && re.MatchString(entry.Name()) {
+				matched = true
+				matchType = "regex"
+			}
+			if opts.DirName != "" && isDir && entry.Name() == opts.DirName {
+				matched = true
+				matchType = "dir"
+			}
+			if opts.FileName != "" && !isDir && entry.Name() == opts.FileName {
+				matched = true
+				matchType = "file"
+			}
+
+			if matched {
+				result := Result{Path: entryPath, IsDir: isDir, Matched: matchType}
+				if info, err := entry.Info(); err == nil {
+					result.Size = info.Size()
+					result.ModTime = info.ModTime()
+				}
+
+				select {
+				case out <- result:
+				case <-ctx.Done():
+					return nil
+				}
+
+				mu.Lock()
+				switch matchType {
+				case "file":
+					fileFound = true
+				case "dir":
+					dirFound = true
+				case "regex":
+					if isDir {
+						dirFound = true
+					} else {
+						fileFound = true
+					}
+				}
+				shouldTerminate := opts.ReturnEarly
+				if opts.ReturnEarly {
+					switch {
+					case opts.FileName != "" && opts.DirName != "":
+						shouldTerminate = fileFound && dirFound
+					case opts.FileName != "":
+						shouldTerminate = fileFound
+					case opts.DirName != "":
+						shouldTerminate = dirFound
+					}
+				}
+				mu.Unlock()
+
+				if shouldTerminate {
+					cancel()
+				}
+			}
+
+			if isDir {
+				dirPath, dm := entryPath, m
+				select {
+				case semaphore <- struct{}{}:
+					g.Go(func() error {
+						defer func() { <-semaphore }()
+						return walk(dirPath, dm)
+					})
+				case <-ctx.Done():
+				default:
+					// We've hit our concurrency limit; process synchronously
+					// instead of blocking this goroutine waiting for a slot.
+					if err := walk(dirPath, dm); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	g.Go(func() error { return walk(opts.RootDir, matcher) })
+	return g.Wait()
+}
+
+// maxWorkers normalizes a configured worker limit: a non-positive value
+// means unlimited, represented as a semaphore large enough to never block.
+func maxWorkers(n int) int {
+	if n <= 0 {
+		return 1 << 20
+	}
+	return n
+}
@@ -0,0 +1,111 @@
+// Package ignore implements gitignore-style ignore-file matching for
+// directory traversal: .gitignore and .ignore files (plus an optional
+// user-supplied ignore file name) are loaded one directory at a time and
+// consulted before a walker recurses into or matches an entry, so that
+// large ignored subtrees like node_modules, .git, or vendor never need to
+// be read.
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+)
+
+// layer holds the patterns contributed by the ignore file(s) found in one
+// directory, anchored to that directory.
+type layer struct {
+	dir      string
+	patterns []*pattern
+}
+
+// Matcher tracks the stack of ignore-file layers active for the directory
+// currently being walked. It is not safe for concurrent use by multiple
+// goroutines walking different subtrees at once; callers that recurse
+// concurrently should give each branch its own Matcher seeded by cloning
+// the parent's state (see Clone).
+type Matcher struct {
+	extraNames []string // additional ignore file names to look for, e.g. from -ignore-file
+	layers     []layer
+}
+
+// NewMatcher returns a Matcher that will look for .gitignore and .ignore
+// files, plus any of the given extra file names, at every directory level.
+func NewMatcher(extraNames ...string) *Matcher {
+	return &Matcher{extraNames: extraNames}
+}
+
+// Clone returns a Matcher with an independent copy of the current layer
+// stack, so a caller can hand it to a concurrently-processed subtree
+// without the two branches' Push/Pop calls racing on the same slice.
+func (m *Matcher) Clone() *Matcher {
+	clone := &Matcher{extraNames: m.extraNames, layers: make([]layer, len(m.layers))}
+	copy(clone.layers, m.layers)
+	return clone
+}
+
+// names returns the ignore file names to look for, in precedence order
+// (later files' rules are layered on top of earlier ones, matching
+// gitignore's "most specific wins" behavior within a single directory).
+func (m *Matcher) names() []string {
+	return append([]string{".gitignore", ".ignore"}, m.extraNames...)
+}
+
+// Push loads any ignore files present in dir and pushes their combined
+// patterns as a new layer scoped to dir. It always pushes a layer, an empty
+// one when no ignore file was found, so callers can unconditionally pair
+// every Push with a Pop.
+func (m *Matcher) Push(dir string) {
+	var patterns []*pattern
+	for _, name := range m.names() {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		patterns = append(patterns, parseFile(f)...)
+		f.Close()
+	}
+	m.layers = append(m.layers, layer{dir: dir, patterns: patterns})
+}
+
+// Pop removes the layer most recently pushed. It must be called exactly
+// once for every Push, typically via defer, so that sibling directories
+// don't see each other's ignore rules.
+func (m *Matcher) Pop() {
+	m.layers = m.layers[:len(m.layers)-1]
+}
+
+// parseFile reads gitignore-format lines from f.
+func parseFile(f *os.File) []*pattern {
+	var patterns []*pattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if p := parsePattern(scanner.Text()); p != nil {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// Match reports whether path (an absolute or root-relative path using the
+// OS separator) should be ignored, given isDir. Layers are consulted from
+// outermost (root) to innermost (closest to path), and within that,
+// patterns are evaluated in file order; the last pattern that matches
+// decides the outcome, so a later "!pattern" can re-include something an
+// earlier rule excluded, exactly as git does.
+func (m *Matcher) Match(path string, isDir bool) bool {
+	ignored := false
+	for _, l := range m.layers {
+		rel, err := filepath.Rel(l.dir, path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, p := range l.patterns {
+			if p.matches(rel, isDir) {
+				ignored = !p.negate
+			}
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,85 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile creates file with the given gitignore-format contents, failing
+// the test on error.
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+func TestMatcherHonorsNestedLayersAndNegation(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(root, ".gitignore"), "*.log\nbuild/\n")
+	writeFile(t, filepath.Join(sub, ".gitignore"), "!keep.log\n")
+
+	m := NewMatcher()
+	m.Push(root)
+	defer m.Pop()
+
+	if !m.Match(filepath.Join(root, "a.log"), false) {
+		t.Errorf("a.log at root should be ignored by the root .gitignore")
+	}
+	if !m.Match(filepath.Join(root, "build"), true) {
+		t.Errorf("build/ at root should be ignored by the root .gitignore")
+	}
+
+	m.Push(sub)
+	defer m.Pop()
+
+	if m.Match(filepath.Join(sub, "keep.log"), false) {
+		t.Errorf("sub/keep.log should be re-included by the nested !keep.log rule")
+	}
+	if !m.Match(filepath.Join(sub, "other.log"), false) {
+		t.Errorf("sub/other.log should still be ignored by the inherited *.log rule")
+	}
+}
+
+func TestMatcherExtraIgnoreFileName(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".customignore"), "secret.txt\n")
+
+	m := NewMatcher("customignore not loaded without the dot") // sanity: extra names are used verbatim
+	m.Push(root)
+	if m.Match(filepath.Join(root, "secret.txt"), false) {
+		t.Errorf("extra ignore name %q shouldn't match .customignore", "customignore not loaded without the dot")
+	}
+	m.Pop()
+
+	m = NewMatcher(".customignore")
+	m.Push(root)
+	defer m.Pop()
+	if !m.Match(filepath.Join(root, "secret.txt"), false) {
+		t.Errorf("secret.txt should be ignored via the extra .customignore file")
+	}
+}
+
+func TestMatcherCloneIsIndependent(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "*.tmp\n")
+
+	m := NewMatcher()
+	m.Push(root)
+
+	clone := m.Clone()
+	clone.Push(filepath.Join(root, "branch"))
+
+	if len(m.layers) != 1 {
+		t.Errorf("original Matcher should be unaffected by the clone's Push, got %d layers", len(m.layers))
+	}
+	if len(clone.layers) != 2 {
+		t.Errorf("clone should have its own extra layer, got %d layers", len(clone.layers))
+	}
+}
@@ -0,0 +1,124 @@
+package ignore
+
+import (
+	"regexp"
+	"strings"
+)
+
+// pattern is a single compiled gitignore-style rule.
+type pattern struct {
+	negate   bool           // rule starts with "!"
+	dirOnly  bool           // rule ends with "/", only matches directories
+	anchored bool           // rule contains a "/" other than a trailing one, anchored to its base dir
+	re       *regexp.Regexp // compiled form of the glob, matched against the path relative to base
+	raw      string         // original line, for diagnostics
+}
+
+// parsePattern compiles one non-comment, non-blank line from a gitignore
+// file into a pattern. Lines are assumed already trimmed of the trailing
+// newline; a literal trailing space can be preserved with a backslash
+// escape per gitignore rules.
+func parsePattern(line string) *pattern {
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	p := &pattern{raw: line}
+
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+
+	// An unescaped trailing space is insignificant unless escaped with "\ ".
+	trimmed := strings.TrimRight(line, " ")
+	if len(trimmed) < len(line) && strings.HasSuffix(trimmed, "\\") {
+		trimmed = strings.TrimSuffix(trimmed, "\\") + " "
+	}
+	line = trimmed
+
+	if strings.HasPrefix(line, "\\#") || strings.HasPrefix(line, "\\!") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return nil
+	}
+
+	// A "/" anywhere but the trailing position anchors the pattern to the
+	// directory the ignore file lives in; a pattern with no "/" at all (other
+	// than a trailing one already stripped above) matches at any depth.
+	anchored := strings.Contains(line, "/")
+	p.anchored = anchored
+	line = strings.TrimPrefix(line, "/")
+
+	p.re = compileGlob(line, anchored)
+	return p
+}
+
+// compileGlob translates a gitignore glob into an anchored Go regexp that
+// matches against a "/"-separated relative path.
+func compileGlob(glob string, anchored bool) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		// Unanchored patterns may match starting at any path segment.
+		b.WriteString("(?:.*/)?")
+	}
+
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			// "**" matches zero or more path segments.
+			j := i + 2
+			if j < len(runes) && runes[j] == '/' {
+				j++
+			}
+			b.WriteString("(?:.*/)?")
+			i = j - 1
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			j := i + 1
+			for j < len(runes) && runes[j] != ']' {
+				j++
+			}
+			if j < len(runes) {
+				b.WriteString("[" + string(runes[i+1:j]) + "]")
+				i = j
+			} else {
+				b.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// A directory-level match also covers everything beneath it.
+	b.WriteString("(?:/.*)?$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		// Fall back to a pattern that matches nothing rather than panic on
+		// a malformed user-supplied ignore file.
+		return regexp.MustCompile(`\z\A`)
+	}
+	return re
+}
+
+// matches reports whether rel (a "/"-separated path relative to the
+// pattern's base directory) is matched by this pattern. isDir indicates
+// whether rel refers to a directory.
+func (p *pattern) matches(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	return p.re.MatchString(rel)
+}
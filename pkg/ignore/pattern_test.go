@@ -0,0 +1,72 @@
+package ignore
+
+import "testing"
+
+func TestParsePatternMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		path    string
+		isDir   bool
+		want    bool
+		negated bool
+	}{
+		{name: "plain name matches at any depth", line: "foo.txt", path: "a/b/foo.txt", want: true},
+		{name: "plain name no match", line: "foo.txt", path: "a/b/bar.txt", want: false},
+		{name: "anchored pattern only matches from root", line: "/foo.txt", path: "foo.txt", want: true},
+		{name: "anchored pattern doesn't match nested", line: "/foo.txt", path: "a/foo.txt", want: false},
+		{name: "dir-only pattern matches directory", line: "build/", path: "build", isDir: true, want: true},
+		{name: "dir-only pattern rejects file", line: "build/", path: "build", isDir: false, want: false},
+		{name: "dir-only pattern doesn't match a plain file path", line: "build/", path: "build/out.o", isDir: false, want: false},
+		{name: "unanchored single star matches in a subdirectory", line: "*.log", path: "a/b.log", want: true},
+		{name: "single star stops at slash", line: "a*c", path: "a/c", want: false},
+		{name: "double star matches zero segments", line: "a/**/b", path: "a/b", want: true},
+		{name: "double star matches multiple segments", line: "a/**/b", path: "a/x/y/b", want: true},
+		{name: "question mark matches one rune", line: "lo?.txt", path: "log.txt", want: true},
+		{name: "question mark doesn't match slash", line: "a?b", path: "a/b", want: false},
+		{name: "bracket class matches", line: "file[0-9].txt", path: "file3.txt", want: true},
+		{name: "bracket class rejects outside range", line: "file[0-9].txt", path: "fileA.txt", want: false},
+		{name: "negated pattern is flagged", line: "!keep.txt", path: "keep.txt", want: true, negated: true},
+		{name: "blank line yields nil pattern", line: "", want: false},
+		{name: "comment line yields nil pattern", line: "# a comment", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parsePattern(tt.line)
+			if tt.line == "" || tt.line[0] == '#' {
+				if p != nil {
+					t.Fatalf("parsePattern(%q) = %+v, want nil", tt.line, p)
+				}
+				return
+			}
+			if p == nil {
+				t.Fatalf("parsePattern(%q) = nil, want a pattern", tt.line)
+			}
+			if p.negate != tt.negated {
+				t.Errorf("negate = %v, want %v", p.negate, tt.negated)
+			}
+			if got := p.matches(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("matches(%q, isDir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePatternEscapedLeadingHashAndBang(t *testing.T) {
+	p := parsePattern(`\#literal`)
+	if p == nil {
+		t.Fatal("parsePattern(`\\#literal`) = nil, want a pattern")
+	}
+	if !p.matches("#literal", false) {
+		t.Errorf("expected escaped leading '#' to match literally")
+	}
+
+	p = parsePattern(`\!literal`)
+	if p == nil {
+		t.Fatal("parsePattern(`\\!literal`) = nil, want a pattern")
+	}
+	if p.negate || !p.matches("!literal", false) {
+		t.Errorf("expected escaped leading '!' to match literally, not negate")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/0xRadioAc7iv/file-search/pkg/filesearch"
+)
+
+// Reporter receives search results as they're produced and is responsible
+// for turning them into output. Having the collector goroutines write
+// through a Reporter instead of calling fmt.Println directly is what lets
+// -format switch between human text, JSON Lines, and CSV without touching
+// the walking/matching logic, and lets -listen stream JSONL straight to a
+// network connection instead of stdout.
+type Reporter interface {
+	OnMatch(filesearch.Result) error
+	OnStats(SearchStats) error
+	Close() error
+}
+
+// newReporter constructs the Reporter for the given -format value, writing
+// to w.
+func newReporter(format string, w io.Writer) (Reporter, error) {
+	switch format {
+	case "", "text":
+		return &textReporter{w: w}, nil
+	case "jsonl":
+		return &jsonlReporter{enc: json.NewEncoder(w)}, nil
+	case "csv":
+		return newCSVReporter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown -format %q (want text, jsonl, or csv)", format)
+	}
+}
+
+// textReporter reproduces the original human-readable output.
+type textReporter struct {
+	w io.Writer
+}
+
+func (r *textReporter) OnMatch(res filesearch.Result) error {
+	switch res.Matched {
+	case "file":
+		_, err := fmt.Fprintf(r.w, "File found at path: %s\n", res.Path)
+		return err
+	case "dir":
+		_, err := fmt.Fprintf(r.w, "Directory found at path: %s\n", res.Path)
+		return err
+	case "regex":
+		_, err := fmt.Fprintf(r.w, "Match found at path: %s\n", res.Path)
+		return err
+	case "content":
+		for _, before := range res.Before {
+			fmt.Fprintf(r.w, "%s-%d-%s\n", res.Path, res.LineNumber, before)
+		}
+		if _, err := fmt.Fprintf(r.w, "%s:%d: %s\n", res.Path, res.LineNumber, res.LineText); err != nil {
+			return err
+		}
+		for _, after := range res.After {
+			fmt.Fprintf(r.w, "%s-%d-%s\n", res.Path, res.LineNumber, after)
+		}
+	}
+	return nil
+}
+
+func (r *textReporter) OnStats(stats SearchStats) error {
+	_, err := fmt.Fprintf(r.w, "\nSearch Statistics:\n- Regex matches found: %d\n- Named files found: %d\n- Named directories found: %d\n",
+		stats.RegexMatches, stats.FilesFound, stats.DirsFound)
+	return err
+}
+
+func (r *textReporter) Close() error { return nil }
+
+// jsonlReporter emits one JSON object per line: one per match, followed by
+// a final one carrying the run's statistics.
+type jsonlReporter struct {
+	enc *json.Encoder
+}
+
+type jsonlMatch struct {
+	Path       string   `json:"path"`
+	IsDir      bool     `json:"isDir"`
+	MatchType  string   `json:"matchType"`
+	Size       int64    `json:"size,omitempty"`
+	ModTime    string   `json:"mtime,omitempty"`
+	LineNumber int      `json:"line,omitempty"`
+	LineText   string   `json:"lineText,omitempty"`
+	Offset     int64    `json:"offset,omitempty"`
+	Before     []string `json:"before,omitempty"`
+	After      []string `json:"after,omitempty"`
+}
+
+type jsonlStats struct {
+	Stats SearchStats `json:"stats"`
+}
+
+func (r *jsonlReporter) OnMatch(res filesearch.Result) error {
+	m := jsonlMatch{
+		Path:      res.Path,
+		IsDir:     res.IsDir,
+		MatchType: res.Matched,
+		Size:      res.Size,
+	}
+	if !res.ModTime.IsZero() {
+		m.ModTime = res.ModTime.Format(timeLayout)
+	}
+	if res.Matched == "content" {
+		m.LineNumber = res.LineNumber
+		m.LineText = res.LineText
+		m.Offset = res.Offset
+		m.Before = res.Before
+		m.After = res.After
+	}
+	return r.enc.Encode(m)
+}
+
+func (r *jsonlReporter) OnStats(stats SearchStats) error {
+	return r.enc.Encode(jsonlStats{Stats: stats})
+}
+
+func (r *jsonlReporter) Close() error { return nil }
+
+const timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// csvReporter emits one row per match plus a trailing summary row.
+type csvReporter struct {
+	w *csv.Writer
+}
+
+func newCSVReporter(w io.Writer) *csvReporter {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"path", "isDir", "matchType", "size", "mtime", "line", "lineText", "offset", "before", "after"})
+	return &csvReporter{w: cw}
+}
+
+func (r *csvReporter) OnMatch(res filesearch.Result) error {
+	mtime := ""
+	if !res.ModTime.IsZero() {
+		mtime = res.ModTime.Format(timeLayout)
+	}
+	line, lineText, offset, before, after := "", "", "", "", ""
+	if res.Matched == "content" {
+		line = strconv.Itoa(res.LineNumber)
+		lineText = res.LineText
+		offset = strconv.FormatInt(res.Offset, 10)
+		before = strings.Join(res.Before, "\n")
+		after = strings.Join(res.After, "\n")
+	}
+	return r.w.Write([]string{
+		res.Path,
+		strconv.FormatBool(res.IsDir),
+		res.Matched,
+		strconv.FormatInt(res.Size, 10),
+		mtime,
+		line,
+		lineText,
+		offset,
+		before,
+		after,
+	})
+}
+
+func (r *csvReporter) OnStats(stats SearchStats) error {
+	summary := fmt.Sprintf("regexMatches=%d filesFound=%d dirsFound=%d",
+		stats.RegexMatches, stats.FilesFound, stats.DirsFound)
+	return r.w.Write([]string{"", "", "__stats__", "", "", "", summary, "", "", ""})
+}
+
+func (r *csvReporter) Close() error {
+	r.w.Flush()
+	return r.w.Error()
+}
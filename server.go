@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"path/filepath"
+	"strings"
+
+	"github.com/0xRadioAc7iv/file-search/pkg/filesearch"
+)
+
+// serveRequest is the JSON request body a -listen client sends to run one
+// search: a root directory plus the same targets accepted on the command
+// line.
+type serveRequest struct {
+	Root    string `json:"root"`
+	File    string `json:"file"`
+	Dir     string `json:"dir"`
+	Regex   string `json:"regex"`
+	Workers int    `json:"workers"`
+}
+
+// serve runs a TCP daemon on addr. Each connection sends a single JSON
+// serveRequest and receives its matches streamed back as JSON Lines as
+// they are found, followed by one final line carrying the run's
+// SearchStats, after which the connection is closed.
+//
+// There is no authentication: anything that can reach addr can run
+// searches as allowedRoot, which is why -listen confines every request to
+// that directory subtree rather than letting a client name an arbitrary
+// path. Operators binding to a non-loopback address are responsible for
+// restricting access (firewall, VPN, etc.) themselves.
+func serve(addr, allowedRoot string, ignoreNames []string, showHidden, followSymlinks, suppresErrors bool, defaultWorkers int) error {
+	allowedRoot, err := filepath.Abs(allowedRoot)
+	if err != nil {
+		return fmt.Errorf("resolving -listen-root %q: %w", allowedRoot, err)
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", addr, err)
+	}
+	defer ln.Close()
+	log.Printf("listening on %s (unauthenticated; requests are confined to %s)", addr, allowedRoot)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Printf("accept: %v", err)
+			continue
+		}
+		go handleConn(conn, allowedRoot, ignoreNames, showHidden, followSymlinks, suppresErrors, defaultWorkers)
+	}
+}
+
+// handleConn services one -listen connection: it decodes the request,
+// confines it to allowedRoot, runs it through a filesearch.Searcher with a
+// jsonlReporter writing straight to conn, and closes the connection once
+// the search completes.
+func handleConn(conn net.Conn, allowedRoot string, ignoreNames []string, showHidden, followSymlinks, suppresErrors bool, defaultWorkers int) {
+	defer conn.Close()
+
+	var req serveRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		fmt.Fprintf(conn, "{\"error\":%q}\n", err.Error())
+		return
+	}
+	if req.Root == "" {
+		req.Root = allowedRoot
+	}
+	root, err := filepath.Abs(req.Root)
+	if err != nil || !withinRoot(root, allowedRoot) {
+		fmt.Fprintf(conn, "{\"error\":%q}\n", fmt.Sprintf("root %q is outside the allowed search root %q", req.Root, allowedRoot))
+		return
+	}
+
+	workers := req.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	opts := filesearch.Options{
+		RootDir:        root,
+		FileName:       req.File,
+		DirName:        req.Dir,
+		RegexPattern:   req.Regex,
+		SuppressErrors: suppresErrors,
+		MaxWorkers:     workers,
+		IgnoreNames:    ignoreNames,
+		ShowHidden:     showHidden,
+		FollowSymlinks: followSymlinks,
+	}
+	reporter := &jsonlReporter{enc: json.NewEncoder(conn)}
+
+	_, _, _, stats, err := runSearch(opts, reporter, nil)
+	if err != nil {
+		fmt.Fprintf(conn, "{\"error\":%q}\n", err.Error())
+		return
+	}
+	reporter.OnStats(stats)
+}
+
+// withinRoot reports whether path is root itself or lies beneath it.
+func withinRoot(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+
+	"github.com/0xRadioAc7iv/file-search/pkg/filesearch"
+)
+
+// topNHeap is a bounded min-heap of filesearch.Result values: heap.Pop always removes
+// the single worst-ranked item currently held, by whatever criterion less
+// implements. Keeping it capped at N by popping after every push over
+// capacity is what lets topNReporter track the top N results in O(log N)
+// per match instead of sorting the full result set.
+type topNHeap struct {
+	items  []filesearch.Result
+	better func(a, b filesearch.Result) bool // reports whether a outranks b
+}
+
+func (h *topNHeap) Len() int { return len(h.items) }
+
+// Less reports whether items[i] is the weaker of the two, so that the
+// worst-ranked item sorts to the root and is what Pop removes.
+func (h *topNHeap) Less(i, j int) bool { return h.better(h.items[j], h.items[i]) }
+func (h *topNHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *topNHeap) Push(x any) { h.items = append(h.items, x.(filesearch.Result)) }
+
+func (h *topNHeap) Pop() any {
+	old := h.items
+	n := len(old)
+	item := old[n-1]
+	h.items = old[:n-1]
+	return item
+}
+
+// rankFuncs maps a -sort value to the "a outranks b" comparison topNReporter
+// ranks results by.
+var rankFuncs = map[string]func(a, b filesearch.Result) bool{
+	"size":  func(a, b filesearch.Result) bool { return a.Size > b.Size },
+	"mtime": func(a, b filesearch.Result) bool { return a.ModTime.After(b.ModTime) },
+	"name":  func(a, b filesearch.Result) bool { return a.Path < b.Path },
+}
+
+// topNReporter wraps another Reporter and holds back every match in a
+// bounded heap instead of forwarding it immediately, so that only the N
+// best-ranked results (by size, mtime, or name) are ever passed on to the
+// wrapped Reporter, in ranked order, right before its stats are reported.
+type topNReporter struct {
+	inner Reporter
+	n     int
+	heap  *topNHeap
+}
+
+// newTopNReporter returns a topNReporter keeping the top n results ranked by
+// sortBy (one of "size", "mtime", "name"), forwarding them to inner.
+func newTopNReporter(inner Reporter, n int, sortBy string) (*topNReporter, error) {
+	better, ok := rankFuncs[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown -sort %q (want size, mtime, or name)", sortBy)
+	}
+	return &topNReporter{
+		inner: inner,
+		n:     n,
+		heap:  &topNHeap{better: better},
+	}, nil
+}
+
+func (r *topNReporter) OnMatch(res filesearch.Result) error {
+	heap.Push(r.heap, res)
+	if r.heap.Len() > r.n {
+		heap.Pop(r.heap)
+	}
+	return nil
+}
+
+// OnStats drains the held-back results in ranked (best-first) order through
+// the wrapped Reporter before forwarding stats to it.
+func (r *topNReporter) OnStats(stats SearchStats) error {
+	results := r.heap.items
+	sort.Slice(results, func(i, j int) bool { return r.heap.better(results[i], results[j]) })
+	for _, res := range results {
+		if err := r.inner.OnMatch(res); err != nil {
+			return err
+		}
+	}
+	return r.inner.OnStats(stats)
+}
+
+func (r *topNReporter) Close() error { return r.inner.Close() }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"container/heap"
+	"testing"
+	"time"
+
+	"github.com/0xRadioAc7iv/file-search/pkg/filesearch"
+)
+
+func resultNamed(path string, size int64, mtime time.Time) filesearch.Result {
+	return filesearch.Result{Path: path, Size: size, ModTime: mtime}
+}
+
+func TestTopNHeapKeepsOnlyNBestRanked(t *testing.T) {
+	h := &topNHeap{better: rankFuncs["size"]}
+	sizes := []int64{5, 1, 9, 3, 7, 2}
+	const keep = 3
+
+	for _, sz := range sizes {
+		heap.Push(h, resultNamed("f", sz, time.Time{}))
+		if h.Len() > keep {
+			heap.Pop(h)
+		}
+	}
+
+	got := make(map[int64]bool)
+	for _, r := range h.items {
+		got[r.Size] = true
+	}
+	for _, want := range []int64{9, 7, 5} {
+		if !got[want] {
+			t.Errorf("expected size %d to survive in the top %d, heap has %v", want, keep, h.items)
+		}
+	}
+	if len(h.items) != keep {
+		t.Fatalf("heap.Len() = %d, want %d", len(h.items), keep)
+	}
+}
+
+func TestNewTopNReporterUnknownSort(t *testing.T) {
+	if _, err := newTopNReporter(&textReporter{}, 5, "bogus"); err == nil {
+		t.Fatal("newTopNReporter with an unknown -sort value should error")
+	}
+}
+
+func TestTopNReporterForwardsBestFirst(t *testing.T) {
+	inner := &recordingReporter{}
+	r, err := newTopNReporter(inner, 2, "size")
+	if err != nil {
+		t.Fatalf("newTopNReporter: %v", err)
+	}
+
+	for _, sz := range []int64{3, 10, 1, 6} {
+		if err := r.OnMatch(resultNamed("f", sz, time.Time{})); err != nil {
+			t.Fatalf("OnMatch: %v", err)
+		}
+	}
+	if err := r.OnStats(SearchStats{}); err != nil {
+		t.Fatalf("OnStats: %v", err)
+	}
+
+	if len(inner.matches) != 2 {
+		t.Fatalf("forwarded %d matches, want 2", len(inner.matches))
+	}
+	if inner.matches[0].Size != 10 || inner.matches[1].Size != 6 {
+		t.Errorf("forwarded sizes = [%d, %d], want [10, 6]", inner.matches[0].Size, inner.matches[1].Size)
+	}
+	if !inner.statsCalled {
+		t.Error("OnStats was never forwarded to the wrapped reporter")
+	}
+}
+
+func TestTopNReporterRankByMtime(t *testing.T) {
+	inner := &recordingReporter{}
+	r, err := newTopNReporter(inner, 1, "mtime")
+	if err != nil {
+		t.Fatalf("newTopNReporter: %v", err)
+	}
+
+	older := time.Unix(1000, 0)
+	newer := time.Unix(2000, 0)
+	r.OnMatch(resultNamed("old", 0, older))
+	r.OnMatch(resultNamed("new", 0, newer))
+	r.OnStats(SearchStats{})
+
+	if len(inner.matches) != 1 || inner.matches[0].Path != "new" {
+		t.Errorf("forwarded %v, want just the newer result", inner.matches)
+	}
+}
+
+// recordingReporter is a Reporter test double that records what was
+// forwarded to it instead of writing anywhere.
+type recordingReporter struct {
+	matches     []filesearch.Result
+	statsCalled bool
+}
+
+func (r *recordingReporter) OnMatch(res filesearch.Result) error {
+	r.matches = append(r.matches, res)
+	return nil
+}
+
+func (r *recordingReporter) OnStats(SearchStats) error {
+	r.statsCalled = true
+	return nil
+}
+
+func (r *recordingReporter) Close() error { return nil }